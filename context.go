@@ -0,0 +1,23 @@
+package main
+
+import "context"
+
+// progressCtxKey 是挂载进度上报函数的 context key 类型
+type progressCtxKey struct{}
+
+// progressFunc 是实际写出 notifications/progress 的底层实现
+type progressFunc func(token any, progress, total float64)
+
+// withProgress 把进度上报函数绑定到 ctx 上，供调用链深处的工具 handler 使用
+func withProgress(ctx context.Context, fn progressFunc) context.Context {
+	return context.WithValue(ctx, progressCtxKey{}, fn)
+}
+
+// Progress 向客户端发送一条 notifications/progress 通知
+// 为什么这样设计：工具 handler 只持有 ctx，不需要关心当前请求绑定了哪个 writer 或传输方式；
+// total 为 0 时省略该字段，表示总量未知
+func Progress(ctx context.Context, token any, progress, total float64) {
+	if fn, ok := ctx.Value(progressCtxKey{}).(progressFunc); ok {
+		fn(token, progress, total)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+// fragmentedReader 模拟网络分片：每次 Read 最多返回 step 字节，用于验证分帧能正确处理半包
+type fragmentedReader struct {
+	data []byte
+	pos  int
+	step int
+}
+
+func (r *fragmentedReader) Read(p []byte) (int, error) {
+	if r.pos >= len(r.data) {
+		return 0, io.EOF
+	}
+	n := r.step
+	if n > len(p) {
+		n = len(p)
+	}
+	if r.pos+n > len(r.data) {
+		n = len(r.data) - r.pos
+	}
+	copy(p, r.data[r.pos:r.pos+n])
+	r.pos += n
+	return n, nil
+}
+
+func lspFrame(body string) string {
+	return fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+// TestLSPFrameReaderFragmented 验证即使底层流被拆成很小的分片，也能正确拼出完整的帧
+func TestLSPFrameReaderFragmented(t *testing.T) {
+	raw := lspFrame(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`) + lspFrame(`{"jsonrpc":"2.0","id":2,"method":"initialize"}`)
+	r := NewLSPFrameReader(&fragmentedReader{data: []byte(raw), step: 3})
+
+	first, err := r.ReadFrame()
+	if err != nil {
+		t.Fatalf("unexpected error on first frame: %v", err)
+	}
+	if string(first) != `{"jsonrpc":"2.0","id":1,"method":"tools/list"}` {
+		t.Fatalf("unexpected first frame body: %s", first)
+	}
+
+	second, err := r.ReadFrame()
+	if err != nil {
+		t.Fatalf("unexpected error on second frame: %v", err)
+	}
+	if string(second) != `{"jsonrpc":"2.0","id":2,"method":"initialize"}` {
+		t.Fatalf("unexpected second frame body: %s", second)
+	}
+
+	if _, err := r.ReadFrame(); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected io.EOF after last frame, got %v", err)
+	}
+}
+
+// TestLSPFrameReaderMalformedResync 验证缺失 Content-Length 的帧头会被报告为 ErrMalformedFrame，
+// 且之后的读取能重新对齐到下一个合法帧
+func TestLSPFrameReaderMalformedResync(t *testing.T) {
+	raw := "Content-Type: application/json\r\n\r\n" + lspFrame(`{"jsonrpc":"2.0","id":1,"method":"ping"}`)
+	r := NewLSPFrameReader(&fragmentedReader{data: []byte(raw), step: 7})
+
+	if _, err := r.ReadFrame(); !errors.Is(err, ErrMalformedFrame) {
+		t.Fatalf("expected ErrMalformedFrame, got %v", err)
+	}
+
+	body, err := r.ReadFrame()
+	if err != nil {
+		t.Fatalf("unexpected error resyncing to next frame: %v", err)
+	}
+	if string(body) != `{"jsonrpc":"2.0","id":1,"method":"ping"}` {
+		t.Fatalf("unexpected resynced frame body: %s", body)
+	}
+}
+
+// TestLSPFrameReaderOversizedPayload 验证声明长度超过上限的帧会被丢弃而不会耗尽内存，
+// 并且读取能继续前进到下一个合法帧
+func TestLSPFrameReaderOversizedPayload(t *testing.T) {
+	oversized := fmt.Sprintf("Content-Length: %d\r\n\r\n", maxLSPFrameSize+1) + string(make([]byte, maxLSPFrameSize+1))
+	raw := oversized + lspFrame(`{"jsonrpc":"2.0","id":1,"method":"ping"}`)
+	r := NewLSPFrameReader(&fragmentedReader{data: []byte(raw), step: 4096})
+
+	if _, err := r.ReadFrame(); !errors.Is(err, ErrMalformedFrame) {
+		t.Fatalf("expected ErrMalformedFrame for oversized payload, got %v", err)
+	}
+
+	body, err := r.ReadFrame()
+	if err != nil {
+		t.Fatalf("unexpected error after oversized payload: %v", err)
+	}
+	if string(body) != `{"jsonrpc":"2.0","id":1,"method":"ping"}` {
+		t.Fatalf("unexpected frame body after resync: %s", body)
+	}
+}
+
+// TestLSPFrameWriterRoundTrip 验证写出的帧能够被读回并得到相同的消息体
+func TestLSPFrameWriterRoundTrip(t *testing.T) {
+	pr, pw := io.Pipe()
+	w := NewLSPFrameWriter(pw)
+	r := NewLSPFrameReader(pr)
+
+	body := []byte(`{"jsonrpc":"2.0","id":1,"result":{}}`)
+	go func() {
+		_ = w.WriteFrame(body)
+		_ = pw.Close()
+	}()
+
+	got, err := r.ReadFrame()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(got) != string(body) {
+		t.Fatalf("expected %s, got %s", body, got)
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// sessionHeader 是 MCP Streamable HTTP 约定的会话关联请求/响应头
+const sessionHeader = "Mcp-Session-Id"
+
+// httpSession 保存单个客户端会话的服务端推送通道
+// 为什么这样做：GET /mcp 建立的 SSE 长连接需要一个地方接收服务端主动发出的通知
+type httpSession struct {
+	id     string
+	notify chan []byte
+	done   chan struct{}
+}
+
+// HTTPTransport 实现 MCP Streamable HTTP 传输
+// 为什么这样做：POST /mcp 承载请求/响应（含按需升级为 SSE），GET /mcp 承载服务端推送，
+// DELETE /mcp 终止会话，三者共用同一份会话表
+type HTTPTransport struct {
+	Addr string
+
+	mu       sync.Mutex
+	sessions map[string]*httpSession
+}
+
+// NewHTTPTransport 创建监听在 addr 上的 HTTPTransport
+func NewHTTPTransport(addr string) *HTTPTransport {
+	return &HTTPTransport{Addr: addr, sessions: make(map[string]*httpSession)}
+}
+
+// Serve 启动 HTTP 服务并阻塞直至出错
+func (t *HTTPTransport) Serve(srv *Server) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mcp", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			t.handlePost(srv, w, r)
+		case http.MethodGet:
+			t.handleGet(w, r)
+		case http.MethodDelete:
+			t.handleDelete(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	return http.ListenAndServe(t.Addr, mux)
+}
+
+// Notify 向所有已连接会话的 SSE 通道广播一条服务端通知
+// 为什么用非阻塞发送：某个会话的 GET /mcp 连接迟迟不消费不应拖慢整个广播
+func (t *HTTPTransport) Notify(method string, params any) {
+	n := JSONRPCNotification{Jsonrpc: "2.0", Method: method, Params: params}
+	b, err := json.Marshal(n)
+	if err != nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, s := range t.sessions {
+		select {
+		case s.notify <- b:
+		default:
+		}
+	}
+}
+
+// newSession 生成一个随机会话 ID 并登记到会话表
+func (t *HTTPTransport) newSession() *httpSession {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	s := &httpSession{id: hex.EncodeToString(b), notify: make(chan []byte, 16), done: make(chan struct{})}
+	t.mu.Lock()
+	t.sessions[s.id] = s
+	t.mu.Unlock()
+	return s
+}
+
+func (t *HTTPTransport) session(id string) *httpSession {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sessions[id]
+}
+
+// sseEventWriter 把每一次 Write 调用的内容包装成一个独立的 SSE data 事件并立即 flush
+// 为什么这样做：Dispatch 针对每条要发给客户端的 JSON-RPC 消息（notifications/progress、
+// 最终响应）各调用一次 Write；把每次调用单独成帧才能让进度通知边产生边流式送达，
+// 而不是被同一个 data: 帧里夹带的换行吞掉
+type sseEventWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+func (s *sseEventWriter) Write(p []byte) (int, error) {
+	if _, err := fmt.Fprintf(s.w, "data: %s\n\n", bytes.TrimRight(p, "\n")); err != nil {
+		return 0, err
+	}
+	s.flusher.Flush()
+	return len(p), nil
+}
+
+// handlePost 处理单个 JSON-RPC 请求
+// 为什么这样做：initialize 请求会分配新会话并通过响应头回传；客户端声明可接受
+// text/event-stream 时把响应改写为 SSE，让 Dispatch 期间产生的每条消息各自成帧流式送达
+func (t *HTTPTransport) handlePost(srv *Server, w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	var req JSONRPCRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "invalid json-rpc request", http.StatusBadRequest)
+		return
+	}
+
+	sid := r.Header.Get(sessionHeader)
+	if req.Method == "initialize" {
+		sid = t.newSession().id
+	}
+	if sid != "" {
+		w.Header().Set(sessionHeader, sid)
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		if flusher, ok := w.(http.Flusher); ok {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+			_ = srv.Dispatch(req, &sseEventWriter{w: w, flusher: flusher})
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = srv.Dispatch(req, w)
+}
+
+// handleGet 建立长连接 SSE 通道用于服务端主动推送（如 notifications/tools/list_changed）
+func (t *HTTPTransport) handleGet(w http.ResponseWriter, r *http.Request) {
+	s := t.session(r.Header.Get(sessionHeader))
+	if s == nil {
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case msg := <-s.notify:
+			fmt.Fprintf(w, "data: %s\n\n", msg)
+			flusher.Flush()
+		case <-s.done:
+			return
+		case <-r.Context().Done():
+			// 客户端断开但没有发 DELETE /mcp：主动清理，避免会话表与其 notify 通道泄漏
+			t.mu.Lock()
+			delete(t.sessions, s.id)
+			t.mu.Unlock()
+			return
+		}
+	}
+}
+
+// handleDelete 终止会话，唤醒其挂起的 SSE 连接并从会话表中移除
+func (t *HTTPTransport) handleDelete(w http.ResponseWriter, r *http.Request) {
+	sid := r.Header.Get(sessionHeader)
+	t.mu.Lock()
+	s, ok := t.sessions[sid]
+	if ok {
+		delete(t.sessions, sid)
+	}
+	t.mu.Unlock()
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	close(s.done)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -1,11 +1,10 @@
 package main
 
 import (
-	"bufio"
 	"encoding/json"
-	"fmt"
+	"flag"
 	"io"
-	"os"
+	"log"
 )
 
 // JSONRPCRequest 表示 JSON-RPC 2.0 请求结构
@@ -90,24 +89,12 @@ type ContentItem struct {
 	Text string `json:"text,omitempty"`
 }
 
-// TwoSumArgs 表示两数之和工具的参数
-// 为什么这样定义：与输入 JSON Schema 保持一致，确保参数校验与业务处理分离
-type TwoSumArgs struct {
-	Nums   []int `json:"nums"`
-	Target int   `json:"target"`
-}
-
-// twoSum 计算两数之和的索引（返回首个匹配）
-// 为什么这样实现：使用哈希表 O(n) 时间复杂度，满足大规模数据性能需求
-func twoSum(nums []int, target int) (int, int, bool) {
-	m := make(map[int]int, len(nums)) // 值 -> 索引
-	for i, v := range nums {
-		if j, ok := m[target-v]; ok {
-			return j, i, true
-		}
-		m[v] = i
-	}
-	return -1, -1, false
+// JSONRPCNotification 表示服务端主动发出的通知
+// 为什么这样定义：通知没有 id 也无需响应，与 JSONRPCRequest/JSONRPCResponse 区分开更清晰
+type JSONRPCNotification struct {
+	Jsonrpc string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
 }
 
 // writeResponse 将响应写入 stdout 并换行分帧
@@ -132,44 +119,6 @@ func writeResponse(w io.Writer, id json.RawMessage, result any, errObj *JSONRPCE
 	return nil
 }
 
-// buildTwoSumSchemas 构造输入输出 JSON Schema
-// 为什么这样做：服务端以原始 JSON 返回 Schema，避免引入第三方库并提升兼容性
-func buildTwoSumSchemas() (json.RawMessage, json.RawMessage) {
-	input := map[string]any{
-		"type":                 "object",
-		"additionalProperties": false,
-		"properties": map[string]any{
-			"nums": map[string]any{
-				"type":        "array",
-				"items":       map[string]any{"type": "integer"},
-				"description": "整数数组",
-			},
-			"target": map[string]any{
-				"type":        "integer",
-				"description": "目标和",
-			},
-		},
-		"required": []string{"nums", "target"},
-	}
-	output := map[string]any{
-		"type":                 "object",
-		"additionalProperties": false,
-		"properties": map[string]any{
-			"indices": map[string]any{
-				"type":        "array",
-				"items":       map[string]any{"type": "integer"},
-				"minItems":    2,
-				"maxItems":    2,
-				"description": "满足两数之和的两个索引",
-			},
-		},
-		"required": []string{"indices"},
-	}
-	ib, _ := json.Marshal(input)
-	ob, _ := json.Marshal(output)
-	return ib, ob
-}
-
 // handleInitialize 处理初始化握手
 // 为什么这样做：必须先完成版本与能力协商，客户端才会继续发送工具请求
 func handleInitialize(id json.RawMessage, params json.RawMessage, w io.Writer) error {
@@ -190,81 +139,32 @@ func handleInitialize(id json.RawMessage, params json.RawMessage, w io.Writer) e
 	return writeResponse(w, id, result, nil)
 }
 
-// handleToolsList 返回工具列表
-// 为什么这样做：客户端通过 tools/list 发现可调用工具
-func handleToolsList(id json.RawMessage, w io.Writer) error {
-	in, out := buildTwoSumSchemas()
-	tools := []ToolDef{
-		{
-			Name:         "two_sum",
-			Title:        "Two Sum",
-			Description:  "返回数组中两元素索引，使其和等于目标值",
-			InputSchema:  in,
-			OutputSchema: out,
-		},
-	}
-	result := ToolsListResult{Tools: tools}
-	return writeResponse(w, id, result, nil)
-}
-
-// handleToolsCall 执行工具调用
-// 为什么这样做：将协议层参数解析与业务逻辑解耦，统一返回结构化与文本结果
-func handleToolsCall(id json.RawMessage, params json.RawMessage, w io.Writer) error {
-	var p ToolsCallParams
-	if err := json.Unmarshal(params, &p); err != nil {
-		return writeResponse(w, id, nil, &JSONRPCErrorObj{Code: -32602, Message: "Invalid params"})
-	}
-	if p.Name != "two_sum" {
-		return writeResponse(w, id, nil, &JSONRPCErrorObj{Code: -32601, Message: "Method not found: unknown tool"})
-	}
-
-	var args TwoSumArgs
-	if err := json.Unmarshal(p.Arguments, &args); err != nil {
-		return writeResponse(w, id, nil, &JSONRPCErrorObj{Code: -32602, Message: "Invalid arguments for two_sum"})
-	}
-
-	i, j, ok := twoSum(args.Nums, args.Target)
-	if !ok {
-		res := ToolsCallResult{
-			Content: []ContentItem{{Type: "text", Text: "未找到符合条件的两个索引"}},
-			IsError: true,
-		}
-		return writeResponse(w, id, res, nil)
-	}
-
-	txt := fmt.Sprintf("indices: [%d,%d]", i, j)
-	res := ToolsCallResult{
-		Content:           []ContentItem{{Type: "text", Text: txt}},
-		StructuredContent: map[string]any{"indices": []int{i, j}},
-		IsError:           false,
-	}
-	return writeResponse(w, id, res, nil)
-}
-
+// main 解析传输方式并启动对应的 Transport
+// 为什么这样做：协议处理与传输方式解耦后，main 只负责装配工具与传输，新增工具/传输都无需改动协议层
 func main() {
-	// 为什么使用 Scanner：简单稳定地逐条读取 JSON-RPC 消息；并提升缓冲以容纳较大消息体
-	scanner := bufio.NewScanner(os.Stdin)
-	buf := make([]byte, 0, 1024*1024)
-	scanner.Buffer(buf, 10*1024*1024) // 最大 10MB
+	transport := flag.String("transport", "stdio", "传输方式：stdio 或 http")
+	addr := flag.String("addr", ":8080", "http 传输监听地址")
+	framing := flag.String("framing", "newline", "stdio 分帧方式：newline 或 lsp（Content-Length 分帧）")
+	maxConcurrent := flag.Int("max-concurrent", 0, "同时执行的 tools/call 数量上限，<=0 表示不限制")
+	flag.Parse()
+
+	var tr Transport
+	switch *transport {
+	case "stdio":
+		tr = NewStdioTransport(*framing)
+	case "http":
+		tr = NewHTTPTransport(*addr)
+	default:
+		log.Fatalf("unknown transport: %s", *transport)
+	}
 
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		var req JSONRPCRequest
-		if err := json.Unmarshal(line, &req); err != nil {
-			// 输入非 JSON 时忽略，保持进程存活以便客户端恢复
-			continue
-		}
+	// 传输方式同时承担 notifications/tools/list_changed 的投递职责
+	sink, _ := tr.(NotificationSink)
+	registry := NewToolRegistry(sink)
+	registerTwoSumTool(registry)
 
-		switch req.Method {
-		case "initialize":
-			_ = handleInitialize(req.ID, req.Params, os.Stdout)
-		case "tools/list":
-			_ = handleToolsList(req.ID, os.Stdout)
-		case "tools/call":
-			_ = handleToolsCall(req.ID, req.Params, os.Stdout)
-		default:
-			// 未知方法返回标准错误，避免客户端阻塞
-			_ = writeResponse(os.Stdout, req.ID, nil, &JSONRPCErrorObj{Code: -32601, Message: "Method not found"})
-		}
+	srv := NewServer(NewDefaultHandler(registry), *maxConcurrent)
+	if err := tr.Serve(srv); err != nil {
+		log.Fatal(err)
 	}
 }
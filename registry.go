@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// ToolHandlerFunc 是已注册工具的执行函数类型
+// 为什么带 ctx：ctx 携带 notifications/cancelled 触发的取消信号以及 Progress 所用的进度上报函数
+type ToolHandlerFunc func(ctx context.Context, args json.RawMessage) (ToolsCallResult, error)
+
+// registeredTool 绑定工具定义与其执行函数
+type registeredTool struct {
+	def     ToolDef
+	handler ToolHandlerFunc
+}
+
+// NotificationSink 用于向已连接的客户端投递服务端主动发起的通知
+// 为什么这样定义：stdio 与 HTTP 投递通知的方式完全不同（写 stdout vs 推送 SSE 通道），
+// ToolRegistry 只需面向这个窄接口，无需感知具体传输细节
+type NotificationSink interface {
+	Notify(method string, params any)
+}
+
+// ToolRegistry 管理可动态注册/注销的工具，并在变化时通过 NotificationSink 广播
+// 为什么这样做：此前 two_sum 写死在协议处理函数中，新增工具需要改动协议层；
+// 有了注册表后，协议层只需对 registry 增删查
+type ToolRegistry struct {
+	mu    sync.RWMutex
+	tools map[string]registeredTool
+	order []string // 保持注册顺序，使 tools/list 的返回结果稳定
+	sink  NotificationSink
+	ready bool // initialize 完成前压住通知，避免在生命周期握手之前就向客户端推送消息
+}
+
+// NewToolRegistry 创建一个空的 ToolRegistry，变更通知通过 sink 投递；sink 为 nil 时不投递
+func NewToolRegistry(sink NotificationSink) *ToolRegistry {
+	return &ToolRegistry{tools: make(map[string]registeredTool), sink: sink}
+}
+
+// Register 注册一个工具（同名工具会被覆盖），随后广播 tools/list_changed
+// （MarkReady 之前或 sink 为 nil 时不广播）
+func (r *ToolRegistry) Register(def ToolDef, handler ToolHandlerFunc) {
+	r.mu.Lock()
+	if _, exists := r.tools[def.Name]; !exists {
+		r.order = append(r.order, def.Name)
+	}
+	r.tools[def.Name] = registeredTool{def: def, handler: handler}
+	r.mu.Unlock()
+	r.notifyListChanged()
+}
+
+// Unregister 移除一个工具并广播 tools/list_changed（条件同 Register）；工具不存在时为空操作
+func (r *ToolRegistry) Unregister(name string) {
+	r.mu.Lock()
+	_, exists := r.tools[name]
+	if exists {
+		delete(r.tools, name)
+		for i, n := range r.order {
+			if n == name {
+				r.order = append(r.order[:i], r.order[i+1:]...)
+				break
+			}
+		}
+	}
+	r.mu.Unlock()
+	if exists {
+		r.notifyListChanged()
+	}
+}
+
+// MarkReady 标记生命周期握手（initialize）已经完成
+// 为什么需要：启动时注册内置工具不应该在客户端完成 initialize 之前就把
+// notifications/tools/list_changed 推到 stdout/SSE 上
+func (r *ToolRegistry) MarkReady() {
+	r.mu.Lock()
+	r.ready = true
+	r.mu.Unlock()
+}
+
+func (r *ToolRegistry) notifyListChanged() {
+	r.mu.RLock()
+	ready := r.ready
+	r.mu.RUnlock()
+	if ready && r.sink != nil {
+		r.sink.Notify("notifications/tools/list_changed", nil)
+	}
+}
+
+// List 返回当前注册的全部工具定义，按注册顺序排列
+func (r *ToolRegistry) List() []ToolDef {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	defs := make([]ToolDef, 0, len(r.order))
+	for _, name := range r.order {
+		defs = append(defs, r.tools[name].def)
+	}
+	return defs
+}
+
+// Call 执行指定工具，对 arguments/structuredContent 分别做输入/输出 Schema 校验
+// 为什么在此处校验：集中校验让每个工具的 handler 只需关心业务逻辑，无需重复解析错误处理
+func (r *ToolRegistry) Call(ctx context.Context, name string, args json.RawMessage) (ToolsCallResult, *JSONRPCErrorObj) {
+	r.mu.RLock()
+	t, ok := r.tools[name]
+	r.mu.RUnlock()
+	if !ok {
+		return ToolsCallResult{}, &JSONRPCErrorObj{Code: -32601, Message: "Method not found: unknown tool"}
+	}
+
+	if len(t.def.InputSchema) > 0 {
+		if violations := validateAgainstSchema(t.def.InputSchema, args); len(violations) > 0 {
+			return ToolsCallResult{}, schemaErrorObj("Invalid arguments for "+name, violations)
+		}
+	}
+
+	res, err := t.handler(ctx, args)
+	if err != nil {
+		return ToolsCallResult{}, &JSONRPCErrorObj{Code: -32603, Message: err.Error()}
+	}
+
+	if len(t.def.OutputSchema) > 0 && res.StructuredContent != nil {
+		b, e := json.Marshal(res.StructuredContent)
+		if e == nil {
+			if violations := validateAgainstSchema(t.def.OutputSchema, b); len(violations) > 0 {
+				return ToolsCallResult{}, schemaErrorObj("Invalid structuredContent for "+name, violations)
+			}
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// schemaViolation 描述一次 JSON Schema 校验失败，用于在 -32602 错误的 data 中按字段呈现
+type schemaViolation struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// schemaErrorObj 构造携带字段级校验详情的 -32602 错误
+func schemaErrorObj(message string, violations []schemaViolation) *JSONRPCErrorObj {
+	data, _ := json.Marshal(map[string]any{"violations": violations})
+	return &JSONRPCErrorObj{Code: -32602, Message: message, Data: data}
+}
+
+// validateAgainstSchema 依据 schema 校验 data，返回所有发现的违规项；schema/data 均为原始 JSON
+// 为什么自行实现：协议只使用 object/array/string/integer/number/boolean 等基础 JSON Schema 子集，
+// 引入第三方 schema 库与 buildTwoSumSchemas 既有的"避免第三方依赖"约定相悖
+func validateAgainstSchema(schema json.RawMessage, data json.RawMessage) []schemaViolation {
+	var s map[string]any
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return nil
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return []schemaViolation{{Field: "$", Message: "invalid JSON"}}
+	}
+	return validateValue("$", s, v)
+}
+
+func validateValue(path string, schema map[string]any, v any) []schemaViolation {
+	var out []schemaViolation
+
+	if t, ok := schema["type"].(string); ok {
+		if !matchesType(t, v) {
+			return append(out, schemaViolation{Field: path, Message: fmt.Sprintf("expected type %s", t)})
+		}
+	}
+
+	switch vv := v.(type) {
+	case map[string]any:
+		props, _ := schema["properties"].(map[string]any)
+		if required, ok := schema["required"].([]any); ok {
+			for _, r := range required {
+				name, _ := r.(string)
+				if _, present := vv[name]; !present {
+					out = append(out, schemaViolation{Field: path + "." + name, Message: "missing required property"})
+				}
+			}
+		}
+		if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+			for k := range vv {
+				if _, known := props[k]; !known {
+					out = append(out, schemaViolation{Field: path + "." + k, Message: "unexpected property"})
+				}
+			}
+		}
+		for k, propSchema := range props {
+			child, present := vv[k]
+			cs, ok := propSchema.(map[string]any)
+			if present && ok {
+				out = append(out, validateValue(path+"."+k, cs, child)...)
+			}
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range vv {
+				out = append(out, validateValue(fmt.Sprintf("%s[%d]", path, i), items, item)...)
+			}
+		}
+		if minItems, ok := schema["minItems"].(float64); ok && float64(len(vv)) < minItems {
+			out = append(out, schemaViolation{Field: path, Message: "too few items"})
+		}
+		if maxItems, ok := schema["maxItems"].(float64); ok && float64(len(vv)) > maxItems {
+			out = append(out, schemaViolation{Field: path, Message: "too many items"})
+		}
+	}
+
+	return out
+}
+
+// matchesType 判断解码后的 JSON 值是否满足 schema 声明的基础类型
+func matchesType(t string, v any) bool {
+	switch t {
+	case "object":
+		_, ok := v.(map[string]any)
+		return ok
+	case "array":
+		_, ok := v.([]any)
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == float64(int64(f))
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	default:
+		return true
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// Handler 定义协议方法的处理能力
+// 为什么这样定义：stdio 与 HTTP 等传输方式需要共享同一套 initialize/tools 处理逻辑，
+// 将其抽成接口后，新增传输只需实现 Transport，无需重复协议代码
+type Handler interface {
+	Initialize(id json.RawMessage, params json.RawMessage, w io.Writer) error
+	ToolsList(id json.RawMessage, w io.Writer) error
+	ToolsCall(ctx context.Context, id json.RawMessage, params json.RawMessage, w io.Writer) error
+}
+
+// defaultHandler 是 Handler 的默认实现，tools/list 与 tools/call 均委托给 ToolRegistry
+// 为什么这样做：协议层不再硬编码任何具体工具，新增/移除工具只需操作注册表
+type defaultHandler struct {
+	registry *ToolRegistry
+}
+
+// NewDefaultHandler 创建一个以 registry 为工具来源的 Handler
+func NewDefaultHandler(registry *ToolRegistry) Handler {
+	return &defaultHandler{registry: registry}
+}
+
+// Initialize 完成生命周期握手，此后注册表的变更才会广播 tools/list_changed；
+// 握手前产生的变更通知已被直接丢弃，这里不会补发
+// 为什么在这里放行：bootstrap 阶段注册的内置工具不应在握手完成前就推送通知给客户端
+func (h *defaultHandler) Initialize(id, params json.RawMessage, w io.Writer) error {
+	err := handleInitialize(id, params, w)
+	h.registry.MarkReady()
+	return err
+}
+
+func (h *defaultHandler) ToolsList(id json.RawMessage, w io.Writer) error {
+	result := ToolsListResult{Tools: h.registry.List()}
+	return writeResponse(w, id, result, nil)
+}
+
+// ToolsCall 执行工具调用，并把一个能向 w 发送 notifications/progress 的上报函数挂在 ctx 上
+// 为什么这样做：工具 handler 只持有 ctx，不需要知道当前请求绑定了哪个 writer/传输方式
+func (h *defaultHandler) ToolsCall(ctx context.Context, id, params json.RawMessage, w io.Writer) error {
+	var p ToolsCallParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return writeResponse(w, id, nil, &JSONRPCErrorObj{Code: -32602, Message: "Invalid params"})
+	}
+
+	ctx = withProgress(ctx, func(token any, progress, total float64) {
+		params := map[string]any{"progressToken": token, "progress": progress}
+		if total != 0 {
+			params["total"] = total
+		}
+		notif := JSONRPCNotification{Jsonrpc: "2.0", Method: "notifications/progress", Params: params}
+		_ = json.NewEncoder(w).Encode(notif)
+	})
+
+	res, errObj := h.registry.Call(ctx, p.Name, p.Arguments)
+	if ctx.Err() != nil {
+		// 请求已被 notifications/cancelled 取消，客户端不再等待这个 id 的响应
+		return nil
+	}
+	if errObj != nil {
+		return writeResponse(w, id, nil, errObj)
+	}
+	return writeResponse(w, id, res, nil)
+}
+
+// CancelParams 表示 notifications/cancelled 携带的参数
+// 为什么这样定义：MCP 约定客户端通过 requestId 引用它想要取消的那个请求
+type CancelParams struct {
+	RequestID json.RawMessage `json:"requestId"`
+	Reason    string          `json:"reason,omitempty"`
+}
+
+// Server 承载协议分发逻辑，并维护正在执行的 tools/call 以支持取消与并发限流
+// 为什么这样定义：Transport 只负责读取消息与投递响应，method 路由、取消、限流统一由 Server 完成
+type Server struct {
+	handler Handler
+	sem     chan struct{} // 为 nil 时不限制并发
+
+	mu       sync.Mutex
+	inflight map[string]context.CancelFunc
+}
+
+// NewServer 创建一个使用给定 Handler 的 Server
+// maxConcurrent <= 0 表示不限制同时执行的 tools/call 数量
+func NewServer(h Handler, maxConcurrent int) *Server {
+	s := &Server{handler: h, inflight: make(map[string]context.CancelFunc)}
+	if maxConcurrent > 0 {
+		s.sem = make(chan struct{}, maxConcurrent)
+	}
+	return s
+}
+
+// Dispatch 根据 JSON-RPC method 路由到具体处理函数，并将响应写入 w
+// 为什么这样做：所有传输方式的消息读取完成后都汇聚到这一处，行为保持一致
+func (s *Server) Dispatch(req JSONRPCRequest, w io.Writer) error {
+	switch req.Method {
+	case "initialize":
+		return s.handler.Initialize(req.ID, req.Params, w)
+	case "tools/list":
+		return s.handler.ToolsList(req.ID, w)
+	case "tools/call":
+		ctx, finish := s.BeginToolsCall(req.ID)
+		defer finish()
+		return s.RunToolsCall(ctx, req, w)
+	case "notifications/cancelled":
+		s.cancel(req.Params)
+		return nil
+	default:
+		// 未知方法返回标准错误，避免客户端阻塞
+		return writeResponse(w, req.ID, nil, &JSONRPCErrorObj{Code: -32601, Message: "Method not found"})
+	}
+}
+
+// BeginToolsCall 为一次 tools/call 建立可取消的 context，并同步登记到 in-flight 表
+// 为什么要拆出来单独同步执行：登记必须在排队等待 --max-concurrent 信号量、或把实际执行
+// 丢给协程之前完成，否则紧随其后到达的 notifications/cancelled 可能在表里找不到对应的
+// cancel 函数，取消请求就被静默丢弃了。调用方须同步调用本函数，实际执行可以放进协程，
+// 并在执行结束后调用 finish 注销登记
+func (s *Server) BeginToolsCall(id json.RawMessage) (ctx context.Context, finish func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	key := string(id)
+	s.mu.Lock()
+	s.inflight[key] = cancel
+	s.mu.Unlock()
+	return ctx, func() {
+		s.mu.Lock()
+		delete(s.inflight, key)
+		s.mu.Unlock()
+		cancel()
+	}
+}
+
+// RunToolsCall 在 --max-concurrent 限制的信号量内执行一次已经建立好 ctx 的 tools/call
+// 为什么与 BeginToolsCall 分开：这一步可能因信号量而阻塞甚至被丢进协程执行，
+// 但 in-flight 登记必须早于它，见 BeginToolsCall 的注释
+func (s *Server) RunToolsCall(ctx context.Context, req JSONRPCRequest, w io.Writer) error {
+	if s.sem != nil {
+		select {
+		case s.sem <- struct{}{}:
+			defer func() { <-s.sem }()
+		case <-ctx.Done():
+			return nil
+		}
+	}
+
+	return s.handler.ToolsCall(ctx, req.ID, req.Params, w)
+}
+
+// cancel 处理 notifications/cancelled：取消对应请求 id 的 context
+func (s *Server) cancel(params json.RawMessage) {
+	var p CancelParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return
+	}
+	key := string(p.RequestID)
+	s.mu.Lock()
+	cancelFn, ok := s.inflight[key]
+	s.mu.Unlock()
+	if ok {
+		cancelFn()
+	}
+}
+
+// Transport 抽象消息的接收与响应投递方式
+// 为什么这样定义：同一个 Server 既可以跑在 stdio 上，也可以跑在 HTTP 等其他通道上
+type Transport interface {
+	Serve(srv *Server) error
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// TestServerCancelsInFlightToolCall 验证 notifications/cancelled 能让仍在执行的 tools/call 的 ctx 被取消
+func TestServerCancelsInFlightToolCall(t *testing.T) {
+	canceled := make(chan struct{})
+	registry := NewToolRegistry(nil)
+	registry.Register(ToolDef{Name: "block"}, func(ctx context.Context, args json.RawMessage) (ToolsCallResult, error) {
+		<-ctx.Done()
+		close(canceled)
+		return ToolsCallResult{}, ctx.Err()
+	})
+
+	srv := NewServer(NewDefaultHandler(registry), 0)
+
+	params, _ := json.Marshal(ToolsCallParams{Name: "block", Arguments: json.RawMessage(`{}`)})
+	req := JSONRPCRequest{Jsonrpc: "2.0", ID: json.RawMessage("1"), Method: "tools/call", Params: params}
+
+	// 模拟 stdio 传输的做法：先同步登记进 in-flight 表，再把实际执行丢到协程里，
+	// 这样紧随其后同步发出的取消一定能在表里找到它，不必依赖任何 sleep
+	var buf bytes.Buffer
+	done := make(chan struct{})
+	ctx, finish := srv.BeginToolsCall(req.ID)
+	go func() {
+		defer finish()
+		_ = srv.RunToolsCall(ctx, req, &buf)
+		close(done)
+	}()
+
+	cancelParams, _ := json.Marshal(CancelParams{RequestID: json.RawMessage("1")})
+	cancelReq := JSONRPCRequest{Jsonrpc: "2.0", Method: "notifications/cancelled", Params: cancelParams}
+	_ = srv.Dispatch(cancelReq, &buf)
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("expected in-flight tool call to be canceled")
+	}
+	<-done
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+// frameWriterAdapter 让 FrameWriter 也能当作 io.Writer 使用
+// 为什么需要：writeResponse/json.Encoder 面向 io.Writer 编程，借助它可以复用同一套协议写入代码，
+// 同时把实际的分帧细节留给具体的 FrameWriter 实现
+type frameWriterAdapter struct {
+	fw FrameWriter
+}
+
+func (a frameWriterAdapter) Write(p []byte) (int, error) {
+	if err := a.fw.WriteFrame(p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// syncFrameWriter 对 FrameWriter 加锁，确保一次 WriteFrame 内的多次底层写入
+// （如 LSP 分帧的 header 与 body）不会与另一个协程的写入交织在一起
+// 为什么需要：请求改为并发分发后，响应与 notifications/progress 可能来自不同协程，
+// 而 stdout 只有一份
+type syncFrameWriter struct {
+	mu sync.Mutex
+	fw FrameWriter
+}
+
+func (s *syncFrameWriter) WriteFrame(body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fw.WriteFrame(body)
+}
+
+// StdioTransport 通过可插拔的 FrameReader/FrameWriter 收发 JSON-RPC 消息
+// 为什么可插拔：按行分帧无法安全处理含字面换行或超限的消息体，LSP 风格的
+// Content-Length 分帧更健壮，但仍需保留按行分帧以兼容旧客户端
+type StdioTransport struct {
+	reader FrameReader
+	writer io.Writer
+}
+
+// NewStdioTransport 创建一个使用指定分帧方式读写标准输入输出的 StdioTransport
+// framing 为 "lsp" 时使用 Content-Length 分帧，其余取值（含空字符串）默认按行分帧
+func NewStdioTransport(framing string) *StdioTransport {
+	if framing == "lsp" {
+		return &StdioTransport{
+			reader: NewLSPFrameReader(os.Stdin),
+			writer: frameWriterAdapter{&syncFrameWriter{fw: NewLSPFrameWriter(os.Stdout)}},
+		}
+	}
+	return &StdioTransport{
+		reader: NewNewlineFrameReader(os.Stdin),
+		writer: frameWriterAdapter{&syncFrameWriter{fw: NewNewlineFrameWriter(os.Stdout)}},
+	}
+}
+
+// Notify 将服务端主动通知写入 stdout
+// 为什么这样做：stdio 没有独立的推送通道，通知与响应共用同一条分帧的输出流
+func (t *StdioTransport) Notify(method string, params any) {
+	n := JSONRPCNotification{Jsonrpc: "2.0", Method: method, Params: params}
+	enc := json.NewEncoder(t.writer)
+	_ = enc.Encode(n)
+}
+
+// Serve 按配置的分帧方式循环读取请求，将 tools/call 分发到独立协程执行，其余方法在
+// 读取循环自身同步处理，直到输入结束
+// 为什么只有 tools/call 走协程：它的执行时间不可控，顺序执行会让后续到达的
+// notifications/cancelled 永远等到当前调用结束才被处理；但 in-flight 登记
+// （BeginToolsCall）必须在读取循环中同步完成——如果连登记本身都丢进协程，
+// 读取循环可能先读到紧随其后的 notifications/cancelled 并在表里扑空，
+// 取消就被静默丢弃了
+// 为什么遇到 ErrMalformedFrame 时不返回：分帧层已经重新同步到下一个帧头，
+// 此时应回复一个 null id 的错误并继续处理后续帧，而不是让整条连接失效
+func (t *StdioTransport) Serve(srv *Server) error {
+	var wg sync.WaitGroup
+	defer wg.Wait() // 输入结束后仍需等待所有已分发的请求跑完，避免进程提前退出
+
+	for {
+		body, err := t.reader.ReadFrame()
+		switch {
+		case errors.Is(err, io.EOF):
+			return nil
+		case errors.Is(err, ErrMalformedFrame):
+			_ = writeResponse(t.writer, json.RawMessage("null"), nil, &JSONRPCErrorObj{Code: -32700, Message: "Parse error: malformed frame"})
+			continue
+		case err != nil:
+			return err
+		}
+
+		var req JSONRPCRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			// 帧边界正确但消息体不是合法 JSON，同样回复一个 null id 的解析错误
+			_ = writeResponse(t.writer, json.RawMessage("null"), nil, &JSONRPCErrorObj{Code: -32700, Message: "Parse error: invalid JSON"})
+			continue
+		}
+
+		if req.Method == "tools/call" {
+			ctx, finish := srv.BeginToolsCall(req.ID)
+			wg.Add(1)
+			go func(req JSONRPCRequest) {
+				defer wg.Done()
+				defer finish()
+				_ = srv.RunToolsCall(ctx, req, t.writer)
+			}(req)
+			continue
+		}
+
+		_ = srv.Dispatch(req, t.writer)
+	}
+}
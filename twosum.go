@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// TwoSumArgs 表示两数之和工具的参数
+// 为什么这样定义：与输入 JSON Schema 保持一致，确保参数校验与业务处理分离
+type TwoSumArgs struct {
+	Nums   []int `json:"nums"`
+	Target int   `json:"target"`
+}
+
+// twoSum 计算两数之和的索引（返回首个匹配）
+// 为什么这样实现：使用哈希表 O(n) 时间复杂度，满足大规模数据性能需求
+func twoSum(nums []int, target int) (int, int, bool) {
+	m := make(map[int]int, len(nums)) // 值 -> 索引
+	for i, v := range nums {
+		if j, ok := m[target-v]; ok {
+			return j, i, true
+		}
+		m[v] = i
+	}
+	return -1, -1, false
+}
+
+// buildTwoSumSchemas 构造输入输出 JSON Schema
+// 为什么这样做：服务端以原始 JSON 返回 Schema，避免引入第三方库并提升兼容性
+func buildTwoSumSchemas() (json.RawMessage, json.RawMessage) {
+	input := map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties": map[string]any{
+			"nums": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "integer"},
+				"description": "整数数组",
+			},
+			"target": map[string]any{
+				"type":        "integer",
+				"description": "目标和",
+			},
+		},
+		"required": []string{"nums", "target"},
+	}
+	output := map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties": map[string]any{
+			"indices": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "integer"},
+				"minItems":    2,
+				"maxItems":    2,
+				"description": "满足两数之和的两个索引",
+			},
+		},
+		"required": []string{"indices"},
+	}
+	ib, _ := json.Marshal(input)
+	ob, _ := json.Marshal(output)
+	return ib, ob
+}
+
+// registerTwoSumTool 将 two_sum 注册为 ToolRegistry 中的第一个工具
+// 为什么这样做：协议层不再硬编码 two_sum，新增工具只需仿照此函数再调用一次 Register
+func registerTwoSumTool(r *ToolRegistry) {
+	in, out := buildTwoSumSchemas()
+	def := ToolDef{
+		Name:         "two_sum",
+		Title:        "Two Sum",
+		Description:  "返回数组中两元素索引，使其和等于目标值",
+		InputSchema:  in,
+		OutputSchema: out,
+	}
+	r.Register(def, func(ctx context.Context, args json.RawMessage) (ToolsCallResult, error) {
+		var a TwoSumArgs
+		if err := json.Unmarshal(args, &a); err != nil {
+			return ToolsCallResult{}, err
+		}
+
+		i, j, ok := twoSum(a.Nums, a.Target)
+		if !ok {
+			return ToolsCallResult{
+				Content: []ContentItem{{Type: "text", Text: "未找到符合条件的两个索引"}},
+				IsError: true,
+			}, nil
+		}
+
+		txt := fmt.Sprintf("indices: [%d,%d]", i, j)
+		return ToolsCallResult{
+			Content:           []ContentItem{{Type: "text", Text: txt}},
+			StructuredContent: map[string]any{"indices": []int{i, j}},
+			IsError:           false,
+		}, nil
+	})
+}